@@ -0,0 +1,71 @@
+package bus
+
+import (
+	"net/http"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// ListenerConfig is the configuration used by On/NewListener to create a
+// nsq consumer and dispatch messages to HandlerFunc.
+type ListenerConfig struct {
+	Lookup             []string
+	Topic              string
+	Channel            string
+	HandlerFunc        HandlerFunc
+	HandlerConcurrency int
+	AutoCreateTopic    bool
+	Config             *nsq.Config
+	// HTTPClient is used for lookupd/nsqd HTTP calls made during topic
+	// discovery and creation. Defaults to a client with a 2s timeout.
+	HTTPClient *http.Client
+	// Middlewares wrap HandlerFunc, outermost first.
+	Middlewares []Middleware
+	// RetryPolicy, when set, controls how failed messages are requeued
+	// before being routed to DeadLetterTopic.
+	RetryPolicy *RetryPolicy
+	// DeadLetterTopic, when set, receives messages that exhausted
+	// RetryPolicy (or whose handler returned ErrSkipRetry). With no
+	// RetryPolicy, it receives every message whose handler failed.
+	DeadLetterTopic string
+}
+
+// EmitterConfig is the configuration used by NewEmitter to create a nsq
+// producer.
+type EmitterConfig struct {
+	Address         string
+	Lookup          []string
+	AutoCreateTopic bool
+	Config          *nsq.Config
+	// HTTPClient is used for lookupd/nsqd HTTP calls made during topic
+	// discovery and creation. Defaults to a client with a 2s timeout.
+	HTTPClient *http.Client
+}
+
+func (lc ListenerConfig) httpClient() *http.Client {
+	if lc.HTTPClient != nil {
+		return lc.HTTPClient
+	}
+	return defaultHTTPClient()
+}
+
+func (ec EmitterConfig) httpClient() *http.Client {
+	if ec.HTTPClient != nil {
+		return ec.HTTPClient
+	}
+	return defaultHTTPClient()
+}
+
+func newListenerConfig(lc ListenerConfig) *nsq.Config {
+	if lc.Config != nil {
+		return lc.Config
+	}
+	return nsq.NewConfig()
+}
+
+func newEmitterConfig(ec EmitterConfig) *nsq.Config {
+	if ec.Config != nil {
+		return ec.Config
+	}
+	return nsq.NewConfig()
+}
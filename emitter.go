@@ -0,0 +1,149 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	nsq "github.com/nsqio/go-nsq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Emitter exposes Emit to publish messages to NSQ topics, and Request to
+// publish a message and await a correlated reply.
+type Emitter struct {
+	*nsq.Producer
+	EmitterConfig
+
+	replyMu       sync.Mutex
+	replyTopic    string
+	replyConsumer atomic.Pointer[nsq.Consumer]
+	pendingMu     sync.Mutex
+	pending       map[string]chan pendingReply
+}
+
+// NewEmitter returns a new Emitter, creating a nsq.Producer against
+// ec.Address (defaulting to localhost:4150).
+func NewEmitter(ec EmitterConfig) (*Emitter, error) {
+	emitter, err := newEmitter(ec)
+	if err != nil {
+		return nil, err
+	}
+
+	registerEmitter(emitter)
+	return emitter, nil
+}
+
+// newEmitter builds an Emitter without registering it with the
+// package-wide registry Shutdown drains. Used both by NewEmitter and by
+// owners (e.g. Listener) that manage an internal emitter's lifecycle
+// themselves.
+func newEmitter(ec EmitterConfig) (*Emitter, error) {
+	if len(ec.Address) == 0 {
+		ec.Address = "localhost:4150"
+	}
+
+	if len(ec.Lookup) == 0 {
+		ec.Lookup = []string{"localhost:4161"}
+	}
+
+	producer, err := nsq.NewProducer(ec.Address, newEmitterConfig(ec))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Emitter{Producer: producer, EmitterConfig: ec}, nil
+}
+
+// newInternalEmitter builds an unregistered Emitter for the package's own
+// internal use (dead-letter publishing, reply delivery), discovering a
+// real producer address from lookup via lookupd rather than silently
+// falling through to the localhost:4150 default when nsqd isn't running
+// on the same host as the caller. The owner (Listener) is responsible for
+// stopping it.
+func newInternalEmitter(lookup []string) (*Emitter, error) {
+	ec := EmitterConfig{Lookup: lookup}
+
+	if addr, err := discoverNsqdAddress(lookup); err == nil {
+		ec.Address = addr
+	}
+
+	return newEmitter(ec)
+}
+
+// Stop stops the emitter's underlying nsq producer and, if Request was
+// ever called, its reply consumer.
+func (e *Emitter) Stop() {
+	if consumer := e.replyConsumer.Load(); consumer != nil {
+		consumer.Stop()
+	}
+	e.Producer.Stop()
+}
+
+// Emit publishes payload, marshaled as JSON, to topic.
+func (e *Emitter) Emit(topic string, payload interface{}) error {
+	return e.EmitWithContext(context.Background(), topic, payload)
+}
+
+// EmitWithContext publishes payload to topic like Emit, additionally
+// injecting ctx's current span context into the message envelope so that
+// a TracingMiddleware on the receiving side can continue the trace.
+func (e *Emitter) EmitWithContext(ctx context.Context, topic string, payload interface{}) error {
+	if err := e.ensureTopic(topic); err != nil {
+		return err
+	}
+
+	return e.emit(ctx, topic, payload, "", "")
+}
+
+// ensureTopic validates topic and, if the emitter is configured for it,
+// auto-creates it. Every publish path (Emit, Request) must go through
+// this before calling emit, so none of them can bypass the typed
+// validation errors or silently skip auto-create.
+func (e *Emitter) ensureTopic(topic string) error {
+	if !IsValidTopicName(topic) {
+		return ErrInvalidTopic
+	}
+
+	if e.AutoCreateTopic && !isEphemeral(topic) && len(e.Lookup) > 0 {
+		client := e.httpClient()
+		exist, err := checkTopic(client, e.Lookup, topic)
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			if err := createTopic(client, topic, e.Lookup); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *Emitter) emit(ctx context.Context, topic string, payload interface{}, replyTo, correlationID string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	traceContext := make(map[string]string)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(traceContext))
+
+	envelope := Message{
+		Payload:       body,
+		ReplyTo:       replyTo,
+		CorrelationID: correlationID,
+		TraceContext:  traceContext,
+	}
+
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return e.Publish(topic, out)
+}
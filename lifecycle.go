@@ -0,0 +1,223 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// Listener manages the lifecycle of a nsq consumer created through
+// NewListener: connecting it, draining it on shutdown, and reporting its
+// stats.
+type Listener struct {
+	lc       ListenerConfig
+	consumer *nsq.Consumer
+
+	// dlqEmitter and replyEmitter are owned by this Listener rather than
+	// the package-wide emitter registry, so a Listener that's built but
+	// never started doesn't leak them, and Stop releases them alongside
+	// the consumer.
+	dlqEmitter   *Emitter
+	replyEmitter *Emitter
+}
+
+// ListenerStats summarizes a Listener's underlying nsq.Consumer.Stats().
+type ListenerStats struct {
+	MessagesReceived uint64
+	MessagesFinished uint64
+	MessagesRequeued uint64
+	MessagesInFlight uint64
+	Connections      int
+}
+
+// NewListener validates lc, auto-creates its topic if configured to, and
+// builds a nsq consumer wired with lc's middlewares and retry policy. The
+// returned Listener is not yet connected to any lookupd; call Start to
+// begin consuming.
+func NewListener(lc ListenerConfig) (*Listener, error) {
+	if len(lc.Topic) == 0 {
+		return nil, ErrTopicRequired
+	}
+
+	if len(lc.Channel) == 0 {
+		return nil, ErrChannelRequired
+	}
+
+	if lc.HandlerFunc == nil {
+		return nil, ErrHandlerRequired
+	}
+
+	if !IsValidTopicName(lc.Topic) {
+		return nil, ErrInvalidTopic
+	}
+
+	if !IsValidChannelName(lc.Channel) {
+		return nil, ErrInvalidChannel
+	}
+
+	if len(lc.Lookup) == 0 {
+		lc.Lookup = []string{"localhost:4161"}
+	}
+
+	if lc.HandlerConcurrency == 0 {
+		lc.HandlerConcurrency = 1
+	}
+
+	// create topic if not exists; ephemeral topics are never registered
+	// with lookupd, so skip the HTTP round-trip entirely.
+	if lc.AutoCreateTopic && !isEphemeral(lc.Topic) {
+		client := lc.httpClient()
+		exist, err := checkTopic(client, lc.Lookup, lc.Topic)
+		if err != nil {
+			return nil, err
+		}
+
+		if !exist {
+			if err := createTopic(client, lc.Topic, lc.Lookup); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var dlqEmitter *Emitter
+	if lc.DeadLetterTopic != "" {
+		dlqEmitter, _ = newInternalEmitter(lc.Lookup)
+	}
+
+	// One reply emitter is shared across every message this listener
+	// handles instead of creating a new one per reply.
+	replyEmitter, _ := newInternalEmitter(lc.Lookup)
+
+	config := newListenerConfig(lc)
+	consumer, err := nsq.NewConsumer(lc.Topic, lc.Channel, config)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer.AddConcurrentHandlers(handleMessage(lc, dlqEmitter, replyEmitter), lc.HandlerConcurrency)
+
+	listener := &Listener{
+		lc:           lc,
+		consumer:     consumer,
+		dlqEmitter:   dlqEmitter,
+		replyEmitter: replyEmitter,
+	}
+	return listener, nil
+}
+
+// Start connects the listener's consumer to its configured lookupds. Only
+// a listener that starts successfully is registered with the package
+// registry, so Shutdown never has to deal with half-initialized listeners.
+func (l *Listener) Start() error {
+	if err := l.consumer.ConnectToNSQLookupds(l.lc.Lookup); err != nil {
+		return err
+	}
+
+	registerListener(l)
+	return nil
+}
+
+// Stop stops the consumer and waits for in-flight messages to drain,
+// bounded by ctx, then releases the listener's internal dlq/reply
+// emitters.
+func (l *Listener) Stop(ctx context.Context) error {
+	l.consumer.Stop()
+
+	var err error
+	select {
+	case <-l.consumer.StopChan:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if l.dlqEmitter != nil {
+		l.dlqEmitter.Stop()
+	}
+
+	if l.replyEmitter != nil {
+		l.replyEmitter.Stop()
+	}
+
+	return err
+}
+
+// Stats summarizes the listener's underlying nsq consumer stats.
+func (l *Listener) Stats() ListenerStats {
+	s := l.consumer.Stats()
+
+	inFlight := s.MessagesReceived - s.MessagesFinished - s.MessagesRequeued
+	return ListenerStats{
+		MessagesReceived: s.MessagesReceived,
+		MessagesFinished: s.MessagesFinished,
+		MessagesRequeued: s.MessagesRequeued,
+		MessagesInFlight: inFlight,
+		Connections:      s.Connections,
+	}
+}
+
+var (
+	registryMu sync.Mutex
+	listeners  []*Listener
+	emitters   []*Emitter
+)
+
+func registerListener(l *Listener) {
+	registryMu.Lock()
+	listeners = append(listeners, l)
+	registryMu.Unlock()
+}
+
+func registerEmitter(e *Emitter) {
+	registryMu.Lock()
+	emitters = append(emitters, e)
+	registryMu.Unlock()
+}
+
+// Shutdown stops every Listener started through this package (via
+// Listener.Start or On) and every Emitter created by NewEmitter, bounded by
+// ctx. A Listener that was never started, or whose Start failed, is not
+// registered and is not stopped by Shutdown. Applications can
+// wire a single signal handler to this instead of tracking every listener
+// and emitter themselves.
+func Shutdown(ctx context.Context) error {
+	registryMu.Lock()
+	ls := append([]*Listener(nil), listeners...)
+	es := append([]*Emitter(nil), emitters...)
+	registryMu.Unlock()
+
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+	)
+
+	for _, l := range ls {
+		wg.Add(1)
+		go func(l *Listener) {
+			defer wg.Done()
+			if err := l.Stop(ctx); err != nil {
+				errsMu.Lock()
+				errs = append(errs, err)
+				errsMu.Unlock()
+			}
+		}(l)
+	}
+
+	for _, e := range es {
+		wg.Add(1)
+		go func(e *Emitter) {
+			defer wg.Done()
+			e.Stop()
+		}(e)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// defaultHTTPTimeout bounds every lookupd/nsqd HTTP call made by the
+// package when the caller hasn't supplied their own http.Client.
+const defaultHTTPTimeout = 2 * time.Second
+
+func defaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+// checkTopic reports whether topic is registered on any of the given
+// lookupd addresses. A single unreachable lookupd doesn't fail the check
+// as long as at least one of them answers; if none do, the aggregated
+// errors are returned.
+func checkTopic(client *http.Client, lookups []string, topic string) (bool, error) {
+	var errs []error
+
+	for _, lookupAddress := range lookups {
+		url := fmt.Sprintf("http://%s/lookup?topic=%s", lookupAddress, topic)
+		resp, err := client.Get(url)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lookupAddress, err))
+			continue
+		}
+
+		status := resp.StatusCode
+		resp.Body.Close()
+
+		switch status {
+		case http.StatusOK:
+			// topic 存在
+			return true, nil
+		case http.StatusNotFound:
+			// topic 不存在 on this lookupd, keep checking the others
+		default:
+			errs = append(errs, fmt.Errorf("%s: unexpected status code: %d", lookupAddress, status))
+		}
+	}
+
+	if len(errs) == len(lookups) && len(errs) > 0 {
+		return false, errors.Join(errs...)
+	}
+
+	return false, nil
+}
+
+// createTopic registers topic on every nsqd producer known to any of the
+// given lookupds, so the topic is created cluster-wide rather than on a
+// single node. Failures to create on an individual nsqd are aggregated
+// and returned, but don't stop the attempt on the remaining nodes.
+func createTopic(client *http.Client, topic string, lookups []string) error {
+	nsqdAddresses, err := nsqdProducers(client, lookups)
+	if err != nil {
+		return err
+	}
+
+	if len(nsqdAddresses) == 0 {
+		return errors.New("no nsqd nodes found in lookup")
+	}
+
+	var errs []error
+	for nsqdAddress := range nsqdAddresses {
+		createURL := fmt.Sprintf("http://%s/topic/create?topic=%s", nsqdAddress, topic)
+		createResp, err := client.Post(createURL, "", nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", nsqdAddress, err))
+			continue
+		}
+
+		status := createResp.StatusCode
+		createResp.Body.Close()
+
+		if status != http.StatusOK {
+			errs = append(errs, fmt.Errorf("%s: failed to create topic: %d", nsqdAddress, status))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// nsqdProducers returns the union, keyed by "host:tcp_port", of every
+// nsqd producer advertised by the given lookupds.
+func nsqdProducers(client *http.Client, lookups []string) (map[string]struct{}, error) {
+	addresses := make(map[string]struct{})
+
+	var errs []error
+	for _, lookupAddress := range lookups {
+		url := fmt.Sprintf("http://%s/nodes", lookupAddress)
+		resp, err := client.Get(url)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lookupAddress, err))
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			errs = append(errs, fmt.Errorf("%s: failed to get nsqd addresses from lookup: %d", lookupAddress, resp.StatusCode))
+			resp.Body.Close()
+			continue
+		}
+
+		var nodes []struct {
+			Address string `json:"broadcast_address"`
+			TCPPort int    `json:"tcp_port"`
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&nodes)
+		resp.Body.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lookupAddress, err))
+			continue
+		}
+
+		for _, node := range nodes {
+			addresses[fmt.Sprintf("%s:%d", node.Address, node.TCPPort)] = struct{}{}
+		}
+	}
+
+	if len(addresses) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return addresses, nil
+}
+
+// discoverNsqdAddress picks one nsqd producer address known to lookups.
+// It's used to give internal emitters (dead-letter publishing, reply
+// delivery) a real producer address instead of silently defaulting to
+// localhost:4150 when nsqd isn't actually running on the caller's host.
+func discoverNsqdAddress(lookups []string) (string, error) {
+	if len(lookups) == 0 {
+		return "", errors.New("no lookupd addresses configured")
+	}
+
+	addresses, err := nsqdProducers(defaultHTTPClient(), lookups)
+	if err != nil {
+		return "", err
+	}
+
+	if len(addresses) == 0 {
+		return "", errors.New("no nsqd nodes found in lookup")
+	}
+
+	picked := make([]string, 0, len(addresses))
+	for addr := range addresses {
+		picked = append(picked, addr)
+	}
+	sort.Strings(picked)
+
+	return picked[0], nil
+}
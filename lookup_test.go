@@ -0,0 +1,203 @@
+package bus
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// addr strips the scheme off a httptest server URL, since lookupAddress/
+// nsqdAddress are always plain "host:port" throughout this package.
+func addr(s *httptest.Server) string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func TestCheckTopic(t *testing.T) {
+	has := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("topic") == "orders" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer has.Close()
+
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer missing.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	client := defaultHTTPClient()
+
+	t.Run("found on one of several lookupds", func(t *testing.T) {
+		exist, err := checkTopic(client, []string{addr(missing), addr(has)}, "orders")
+		if err != nil || !exist {
+			t.Fatalf("checkTopic() = %v, %v, want true, nil", exist, err)
+		}
+	})
+
+	t.Run("not found on any lookupd", func(t *testing.T) {
+		exist, err := checkTopic(client, []string{addr(missing)}, "orders")
+		if err != nil || exist {
+			t.Fatalf("checkTopic() = %v, %v, want false, nil", exist, err)
+		}
+	})
+
+	t.Run("all lookupds erroring returns aggregated error", func(t *testing.T) {
+		exist, err := checkTopic(client, []string{addr(broken), "127.0.0.1:1"}, "orders")
+		if exist {
+			t.Fatal("checkTopic() reported exist=true, want false")
+		}
+		if err == nil {
+			t.Fatal("checkTopic() err = nil, want an aggregated error")
+		}
+	})
+
+	t.Run("a broken lookupd doesn't shadow a working one", func(t *testing.T) {
+		exist, err := checkTopic(client, []string{addr(broken), addr(has)}, "orders")
+		if err != nil || !exist {
+			t.Fatalf("checkTopic() = %v, %v, want true, nil", exist, err)
+		}
+	})
+}
+
+func TestNsqdProducers(t *testing.T) {
+	nodesHandler := func(nodes ...map[string]interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/nodes" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(nodes)
+		}
+	}
+
+	lookupA := httptest.NewServer(nodesHandler(
+		map[string]interface{}{"broadcast_address": "10.0.0.1", "tcp_port": 4150},
+	))
+	defer lookupA.Close()
+
+	lookupB := httptest.NewServer(nodesHandler(
+		map[string]interface{}{"broadcast_address": "10.0.0.1", "tcp_port": 4150},
+		map[string]interface{}{"broadcast_address": "10.0.0.2", "tcp_port": 4150},
+	))
+	defer lookupB.Close()
+
+	client := defaultHTTPClient()
+
+	t.Run("unions and dedupes across lookupds", func(t *testing.T) {
+		got, err := nsqdProducers(client, []string{addr(lookupA), addr(lookupB)})
+		if err != nil {
+			t.Fatalf("nsqdProducers() err = %v", err)
+		}
+
+		want := map[string]struct{}{"10.0.0.1:4150": {}, "10.0.0.2:4150": {}}
+		if len(got) != len(want) {
+			t.Fatalf("nsqdProducers() = %v, want %v", got, want)
+		}
+		for k := range want {
+			if _, ok := got[k]; !ok {
+				t.Errorf("nsqdProducers() missing %q", k)
+			}
+		}
+	})
+
+	t.Run("all lookupds unreachable returns an error", func(t *testing.T) {
+		_, err := nsqdProducers(client, []string{"127.0.0.1:1"})
+		if err == nil {
+			t.Fatal("nsqdProducers() err = nil, want error")
+		}
+	})
+}
+
+func TestCreateTopic(t *testing.T) {
+	var createCalls int
+	nsqd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/topic/create" {
+			createCalls++
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer nsqd.Close()
+
+	host, port := splitHostPort(t, addr(nsqd))
+
+	lookup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nodes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"broadcast_address": host, "tcp_port": port},
+		})
+	}))
+	defer lookup.Close()
+
+	client := defaultHTTPClient()
+
+	if err := createTopic(client, "orders", []string{addr(lookup)}); err != nil {
+		t.Fatalf("createTopic() err = %v", err)
+	}
+	if createCalls != 1 {
+		t.Fatalf("createTopic() called nsqd's /topic/create %d times, want 1", createCalls)
+	}
+
+	t.Run("no nsqd nodes known", func(t *testing.T) {
+		empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode([]map[string]interface{}{})
+		}))
+		defer empty.Close()
+
+		if err := createTopic(client, "orders", []string{addr(empty)}); err == nil {
+			t.Fatal("createTopic() err = nil, want error when no nsqd nodes are known")
+		}
+	})
+}
+
+func TestDiscoverNsqdAddress(t *testing.T) {
+	lookup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"broadcast_address": "10.0.0.2", "tcp_port": 4150},
+			{"broadcast_address": "10.0.0.1", "tcp_port": 4150},
+		})
+	}))
+	defer lookup.Close()
+
+	got, err := discoverNsqdAddress([]string{addr(lookup)})
+	if err != nil {
+		t.Fatalf("discoverNsqdAddress() err = %v", err)
+	}
+	if want := "10.0.0.1:4150"; got != want {
+		t.Errorf("discoverNsqdAddress() = %q, want %q", got, want)
+	}
+
+	if _, err := discoverNsqdAddress(nil); err == nil {
+		t.Error("discoverNsqdAddress(nil) err = nil, want error")
+	}
+}
+
+func splitHostPort(t *testing.T, hostport string) (string, int) {
+	t.Helper()
+
+	i := strings.LastIndex(hostport, ":")
+	if i < 0 {
+		t.Fatalf("splitHostPort(%q): no port", hostport)
+	}
+
+	port, err := strconv.Atoi(hostport[i+1:])
+	if err != nil {
+		t.Fatalf("splitHostPort(%q): %v", hostport, err)
+	}
+
+	return hostport[:i], port
+}
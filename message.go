@@ -0,0 +1,42 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// Message wraps *nsq.Message, adding the envelope fields the bus package
+// uses to route replies, carry the JSON-encoded payload, and propagate
+// tracing context across topics.
+type Message struct {
+	*nsq.Message
+	Payload       json.RawMessage   `json:"payload"`
+	ReplyTo       string            `json:"reply_to,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	TraceContext  map[string]string `json:"trace_context,omitempty"`
+
+	ctx context.Context
+}
+
+// DecodePayload unmarshals the message payload into v.
+func (m *Message) DecodePayload(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// Context returns the context associated with the message. It defaults to
+// context.Background() unless a middleware (e.g. TracingMiddleware) has
+// attached one.
+func (m *Message) Context() context.Context {
+	if m.ctx == nil {
+		return context.Background()
+	}
+	return m.ctx
+}
+
+// WithContext attaches ctx to the message, returning m for chaining.
+func (m *Message) WithContext(ctx context.Context) *Message {
+	m.ctx = ctx
+	return m
+}
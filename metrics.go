@@ -0,0 +1,34 @@
+package bus
+
+import "time"
+
+// Metrics is the narrow interface MetricsMiddleware reports through. It is
+// shaped so that a thin adapter over either expvar or a
+// prometheus.Collector can satisfy it without this package depending on
+// either.
+type Metrics interface {
+	IncCounter(name string, labels map[string]string)
+	ObserveLatency(name string, labels map[string]string, seconds float64)
+}
+
+// MetricsMiddleware records handler outcome counts and latency for the
+// given topic/channel pair via metrics.
+func MetricsMiddleware(metrics Metrics, topic, channel string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(m *Message) (interface{}, error) {
+			start := time.Now()
+			res, err := next(m)
+
+			result := "ok"
+			if err != nil {
+				result = "error"
+			}
+
+			labels := map[string]string{"topic": topic, "channel": channel, "result": result}
+			metrics.IncCounter("bus_handler_total", labels)
+			metrics.ObserveLatency("bus_handler_duration_seconds", labels, time.Since(start).Seconds())
+
+			return res, err
+		}
+	}
+}
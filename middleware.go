@@ -0,0 +1,59 @@
+package bus
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a HandlerFunc to add cross-cutting behaviour (logging,
+// metrics, tracing, panic recovery, ...) around the user-supplied handler.
+type Middleware func(HandlerFunc) HandlerFunc
+
+// chainMiddlewares composes middlewares around handler in order, so that
+// middlewares[0] is the outermost wrapper and runs first.
+func chainMiddlewares(handler HandlerFunc, middlewares []Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Logger is the minimal logging interface LoggingMiddleware and
+// RecoverMiddleware write to. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// RecoverMiddleware recovers panics raised by the wrapped handler, logs
+// the stack trace to logger, and turns the panic into an error so the
+// message is requeued instead of crashing the consumer goroutine.
+func RecoverMiddleware(logger Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(m *Message) (res interface{}, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if logger != nil {
+						logger.Printf("bus: recovered panic handling message %x: %v\n%s", m.ID, r, debug.Stack())
+					}
+					err = fmt.Errorf("bus: handler panicked: %v", r)
+				}
+			}()
+
+			return next(m)
+		}
+	}
+}
+
+// LoggingMiddleware logs the outcome and duration of every handled
+// message to logger.
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(m *Message) (interface{}, error) {
+			start := time.Now()
+			res, err := next(m)
+			logger.Printf("bus: handled message %x in %s, err=%v", m.ID, time.Since(start), err)
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package bus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// pendingReply carries the result delivered to a Request call waiting on
+// a particular correlation ID.
+type pendingReply struct {
+	payload json.RawMessage
+	err     error
+}
+
+// Request publishes payload to topic and blocks until a reply carrying a
+// matching correlation ID arrives on the emitter's dedicated reply topic,
+// or ctx is cancelled. The reply payload is unmarshaled into reply.
+//
+// A single background consumer per Emitter owns the reply topic/channel,
+// so concurrent Request calls don't each pay a subscribe cost.
+func (e *Emitter) Request(ctx context.Context, topic string, payload interface{}, reply interface{}) error {
+	if err := e.ensureTopic(topic); err != nil {
+		return err
+	}
+
+	if err := e.ensureReplyConsumer(); err != nil {
+		return err
+	}
+
+	correlationID := newCorrelationID()
+	waiter := make(chan pendingReply, 1)
+
+	e.pendingMu.Lock()
+	e.pending[correlationID] = waiter
+	e.pendingMu.Unlock()
+
+	defer func() {
+		e.pendingMu.Lock()
+		delete(e.pending, correlationID)
+		e.pendingMu.Unlock()
+	}()
+
+	if err := e.emit(ctx, topic, payload, e.replyTopic, correlationID); err != nil {
+		return err
+	}
+
+	return waitForReply(ctx, waiter, reply)
+}
+
+// waitForReply blocks until either ctx is done or waiter receives the
+// reply dispatched by handleReply, unmarshaling its payload into reply.
+// Split out from Request so the wait itself can be exercised without a
+// live nsqd.
+func waitForReply(ctx context.Context, waiter chan pendingReply, reply interface{}) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-waiter:
+		if res.err != nil {
+			return res.err
+		}
+		return json.Unmarshal(res.payload, reply)
+	}
+}
+
+// ensureReplyConsumer lazily creates the ephemeral reply topic/channel
+// this emitter listens on for Request responses. Unlike a sync.Once,
+// a failed setup attempt (e.g. lookupd unreachable) is retried on the
+// next call instead of being permanently remembered as "done".
+func (e *Emitter) ensureReplyConsumer() error {
+	if e.replyConsumer.Load() != nil {
+		return nil
+	}
+
+	e.replyMu.Lock()
+	defer e.replyMu.Unlock()
+
+	if e.replyConsumer.Load() != nil {
+		return nil
+	}
+
+	if e.pending == nil {
+		e.pendingMu.Lock()
+		e.pending = make(map[string]chan pendingReply)
+		e.pendingMu.Unlock()
+	}
+
+	if e.replyTopic == "" {
+		e.replyTopic = fmt.Sprintf("bus.reply.%s#ephemeral", newCorrelationID())
+	}
+
+	consumer, err := nsq.NewConsumer(e.replyTopic, "reply#ephemeral", newEmitterConfig(e.EmitterConfig))
+	if err != nil {
+		return err
+	}
+
+	consumer.AddHandler(nsq.HandlerFunc(e.handleReply))
+
+	if err := consumer.ConnectToNSQLookupds(e.Lookup); err != nil {
+		return err
+	}
+
+	e.replyConsumer.Store(consumer)
+	return nil
+}
+
+func (e *Emitter) handleReply(message *nsq.Message) error {
+	var m Message
+	if err := json.Unmarshal(message.Body, &m); err != nil {
+		return err
+	}
+
+	e.pendingMu.Lock()
+	waiter, ok := e.pending[m.CorrelationID]
+	e.pendingMu.Unlock()
+
+	if !ok {
+		// No one is waiting for this reply anymore (Request already timed
+		// out, or the correlation ID is unknown); drop it.
+		return nil
+	}
+
+	waiter <- pendingReply{payload: m.Payload}
+	return nil
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
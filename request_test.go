@@ -0,0 +1,123 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+func TestWaitForReplyTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	waiter := make(chan pendingReply, 1)
+
+	var reply json.RawMessage
+	if err := waitForReply(ctx, waiter, &reply); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waitForReply() err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestWaitForReplyDeliversPayload(t *testing.T) {
+	waiter := make(chan pendingReply, 1)
+	waiter <- pendingReply{payload: json.RawMessage(`{"ok":true}`)}
+
+	var reply struct {
+		OK bool `json:"ok"`
+	}
+	if err := waitForReply(context.Background(), waiter, &reply); err != nil {
+		t.Fatalf("waitForReply() err = %v", err)
+	}
+	if !reply.OK {
+		t.Fatal("waitForReply() didn't unmarshal the delivered payload into reply")
+	}
+}
+
+func TestWaitForReplyPropagatesError(t *testing.T) {
+	waiter := make(chan pendingReply, 1)
+	wantErr := errors.New("boom")
+	waiter <- pendingReply{err: wantErr}
+
+	var reply json.RawMessage
+	if err := waitForReply(context.Background(), waiter, &reply); !errors.Is(err, wantErr) {
+		t.Fatalf("waitForReply() err = %v, want %v", err, wantErr)
+	}
+}
+
+// TestHandleReplyDispatchesToCorrectWaiter exercises the pending
+// correlation map under concurrent reply delivery: a waiter is registered
+// for each correlation ID up front, then the replies are dispatched from
+// concurrent goroutines and each waiter must receive only its own reply.
+func TestHandleReplyDispatchesToCorrectWaiter(t *testing.T) {
+	e := &Emitter{pending: make(map[string]chan pendingReply)}
+
+	const n = 50
+	waiters := make(map[string]chan pendingReply, n)
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("id-%d", i)
+		waiter := make(chan pendingReply, 1)
+
+		e.pendingMu.Lock()
+		e.pending[id] = waiter
+		e.pendingMu.Unlock()
+
+		waiters[id] = waiter
+	}
+
+	var wg sync.WaitGroup
+	for id := range waiters {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			body, err := json.Marshal(Message{CorrelationID: id, Payload: json.RawMessage(fmt.Sprintf("%q", id))})
+			if err != nil {
+				t.Errorf("marshal reply for %s: %v", id, err)
+				return
+			}
+
+			if err := e.handleReply(&nsq.Message{Body: body}); err != nil {
+				t.Errorf("handleReply(%s): %v", id, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	for id, waiter := range waiters {
+		select {
+		case res := <-waiter:
+			var got string
+			if err := json.Unmarshal(res.payload, &got); err != nil {
+				t.Errorf("unmarshal reply payload for %s: %v", id, err)
+				continue
+			}
+			if got != id {
+				t.Errorf("waiter %s received reply for %s", id, got)
+			}
+		default:
+			t.Errorf("waiter %s never received a reply", id)
+		}
+	}
+}
+
+// TestHandleReplyUnknownCorrelationID matches the case of a reply arriving
+// after Request has already given up (ctx cancelled, waiter removed from
+// e.pending): it must be dropped, not error or block.
+func TestHandleReplyUnknownCorrelationID(t *testing.T) {
+	e := &Emitter{pending: make(map[string]chan pendingReply)}
+
+	body, err := json.Marshal(Message{CorrelationID: "missing"})
+	if err != nil {
+		t.Fatalf("marshal reply: %v", err)
+	}
+
+	if err := e.handleReply(&nsq.Message{Body: body}); err != nil {
+		t.Fatalf("handleReply() err = %v, want nil", err)
+	}
+}
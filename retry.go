@@ -0,0 +1,109 @@
+package bus
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	nsq "github.com/nsqio/go-nsq"
+)
+
+// ErrSkipRetry lets a handler short-circuit past RetryPolicy straight to
+// ListenerConfig.DeadLetterTopic.
+var ErrSkipRetry = errors.New("bus: skip retry")
+
+// RetryPolicy configures how handleMessage retries a message that
+// HandlerFunc failed on before giving up and routing it to
+// ListenerConfig.DeadLetterTopic. A nil RetryPolicy leaves go-nsq's
+// default requeue-with-backoff behaviour in place when no DeadLetterTopic
+// is set; with a DeadLetterTopic set, a failed message is routed there on
+// its first failure.
+type RetryPolicy struct {
+	MaxAttempts    uint16
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+}
+
+// backoff computes the requeue delay for the given (1-indexed) attempt.
+func (p *RetryPolicy) backoff(attempt uint16) time.Duration {
+	if p == nil || p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	if attempt == 0 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * rand.Float64()
+	}
+
+	return time.Duration(delay)
+}
+
+// deadLetter is the body published to ListenerConfig.DeadLetterTopic once
+// a message exhausts its retry attempts.
+type deadLetter struct {
+	Body          json.RawMessage `json:"body"`
+	OriginalTopic string          `json:"original_topic"`
+	Attempts      uint16          `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	FirstSeen     time.Time       `json:"first_seen"`
+}
+
+// handleFailure decides what to do with a message whose HandlerFunc
+// returned handlerErr: requeue it per lc.RetryPolicy, route it to
+// lc.DeadLetterTopic via dlqEmitter, or leave it to go-nsq's default
+// behaviour. It reports whether it has already responded to message
+// (Requeue/Finish), in which case the caller must not also return an
+// error to go-nsq.
+func (lc ListenerConfig) handleFailure(message *nsq.Message, handlerErr error, dlqEmitter *Emitter) bool {
+	skipRetry := errors.Is(handlerErr, ErrSkipRetry)
+
+	if !skipRetry && lc.RetryPolicy != nil {
+		attempt := message.Attempts
+		if lc.RetryPolicy.MaxAttempts == 0 || attempt < lc.RetryPolicy.MaxAttempts {
+			message.DisableAutoResponse()
+			message.RequeueWithoutBackoff(lc.RetryPolicy.backoff(attempt))
+			return true
+		}
+	}
+
+	if lc.DeadLetterTopic == "" || dlqEmitter == nil {
+		return false
+	}
+
+	message.DisableAutoResponse()
+
+	dl := deadLetter{
+		Body:          message.Body,
+		OriginalTopic: lc.Topic,
+		Attempts:      message.Attempts,
+		LastError:     handlerErr.Error(),
+		FirstSeen:     time.Unix(0, message.Timestamp),
+	}
+
+	if err := dlqEmitter.Emit(lc.DeadLetterTopic, dl); err != nil {
+		// Couldn't hand it off to the dead-letter topic; requeue rather
+		// than drop the message on the floor.
+		message.RequeueWithoutBackoff(lc.RetryPolicy.backoff(message.Attempts))
+		return true
+	}
+
+	message.Finish()
+	return true
+}
@@ -0,0 +1,81 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  *RetryPolicy
+		attempt uint16
+		want    time.Duration
+	}{
+		{"nil policy", nil, 1, 0},
+		{"zero InitialBackoff", &RetryPolicy{}, 1, 0},
+		{
+			"first attempt equals InitialBackoff",
+			&RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			1,
+			time.Second,
+		},
+		{
+			"attempt zero treated as first attempt",
+			&RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			0,
+			time.Second,
+		},
+		{
+			"multiplier doubles each attempt",
+			&RetryPolicy{InitialBackoff: time.Second, Multiplier: 2},
+			3,
+			4 * time.Second,
+		},
+		{
+			"multiplier defaults to 1 when unset",
+			&RetryPolicy{InitialBackoff: time.Second},
+			5,
+			time.Second,
+		},
+		{
+			"MaxBackoff caps the delay",
+			&RetryPolicy{InitialBackoff: time.Second, Multiplier: 2, MaxBackoff: 3 * time.Second},
+			10,
+			3 * time.Second,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.policy.backoff(c.attempt); got != c.want {
+				t.Errorf("backoff(%d) = %v, want %v", c.attempt, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoffJitterAddsDelay(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: time.Second, Multiplier: 1, Jitter: 0.5}
+
+	base := time.Second
+	for i := 0; i < 20; i++ {
+		got := p.backoff(1)
+		if got < base {
+			t.Fatalf("backoff with jitter = %v, want >= base delay %v", got, base)
+		}
+		if got > base+base/2 {
+			t.Fatalf("backoff with jitter = %v, want <= base*1.5 %v", got, base+base/2)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffNoJitterIsDeterministic(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: time.Second, Multiplier: 2}
+
+	first := p.backoff(2)
+	second := p.backoff(2)
+	if first != second {
+		t.Errorf("backoff should be deterministic when Jitter is 0, got %v and %v", first, second)
+	}
+}
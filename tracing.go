@@ -0,0 +1,30 @@
+package bus
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var tracer = otel.Tracer("github.com/alsritter/nsq-event-bus")
+
+// TracingMiddleware extracts an OpenTelemetry span context from the
+// message's TraceContext carrier (populated by Emitter.EmitWithContext on
+// the sending side) and starts a child span around the wrapped handler.
+// The resulting context is attached to the message so handlers, and any
+// reply emitted from within them, continue the same trace.
+func TracingMiddleware(next HandlerFunc) HandlerFunc {
+	return func(m *Message) (interface{}, error) {
+		ctx := context.Background()
+		if len(m.TraceContext) > 0 {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(m.TraceContext))
+		}
+
+		ctx, span := tracer.Start(ctx, "bus.HandlerFunc")
+		defer span.End()
+
+		m.WithContext(ctx)
+		return next(m)
+	}
+}
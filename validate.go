@@ -0,0 +1,47 @@
+package bus
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidTopic is returned when a topic name doesn't satisfy nsqd's
+	// naming rules.
+	ErrInvalidTopic = errors.New("bus: invalid topic name")
+	// ErrInvalidChannel is returned when a channel name doesn't satisfy
+	// nsqd's naming rules.
+	ErrInvalidChannel = errors.New("bus: invalid channel name")
+)
+
+// validNameRegexp mirrors the rule nsqd itself enforces in
+// internal/protocol.IsValidTopicName/IsValidChannelName.
+var validNameRegexp = regexp.MustCompile(`^[.a-zA-Z0-9_-]+(#ephemeral)?$`)
+
+const maxNameLength = 64
+
+// IsValidTopicName reports whether name is an acceptable nsqd topic name,
+// including the #ephemeral suffix.
+func IsValidTopicName(name string) bool {
+	return isValidName(name)
+}
+
+// IsValidChannelName reports whether name is an acceptable nsqd channel
+// name, including the #ephemeral suffix.
+func IsValidChannelName(name string) bool {
+	return isValidName(name)
+}
+
+func isValidName(name string) bool {
+	if len(name) == 0 || len(name) > maxNameLength {
+		return false
+	}
+	return validNameRegexp.MatchString(name)
+}
+
+// isEphemeral reports whether name carries the #ephemeral suffix nsqd uses
+// to mark topics/channels that should not be persisted or auto-created.
+func isEphemeral(name string) bool {
+	return strings.HasSuffix(name, "#ephemeral")
+}
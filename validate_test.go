@@ -0,0 +1,58 @@
+package bus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsValidTopicName(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{"empty", "", false},
+		{"simple", "orders", true},
+		{"dots underscores dashes", "orders.created_v2-beta", true},
+		{"ephemeral suffix", "orders#ephemeral", true},
+		{"colon is invalid", "test:ephemeral", false},
+		{"at max length", strings.Repeat("a", maxNameLength), true},
+		{"over max length", strings.Repeat("a", maxNameLength+1), false},
+		{"ephemeral suffix over max length", strings.Repeat("a", maxNameLength) + "#ephemeral", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsValidTopicName(c.in); got != c.want {
+				t.Errorf("IsValidTopicName(%q) = %v, want %v", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsValidChannelName(t *testing.T) {
+	if !IsValidChannelName("billing#ephemeral") {
+		t.Error("expected ephemeral channel name to be valid")
+	}
+
+	if IsValidChannelName("") {
+		t.Error("expected empty channel name to be invalid")
+	}
+}
+
+func TestIsEphemeral(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"orders", false},
+		{"orders#ephemeral", true},
+		{"bus.reply.abc123#ephemeral", true},
+	}
+
+	for _, c := range cases {
+		if got := isEphemeral(c.in); got != c.want {
+			t.Errorf("isEphemeral(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}